@@ -0,0 +1,47 @@
+package redshift
+
+import (
+	"database/sql"
+	"sync"
+)
+
+//Client wraps a *sql.DB connection along with capabilities of the connected
+//Redshift endpoint that resources need to adapt the SQL they emit, such as
+//whether the endpoint is Redshift Serverless rather than a provisioned cluster.
+//Terraform shares a single Client across concurrent resource CRUD calls via
+//the provider's meta interface{}, so the capability probe is run and cached
+//exactly once via sync.Once rather than with an unsynchronized *bool.
+type Client struct {
+	db             *sql.DB
+	serverlessOnce sync.Once
+	isServerless   bool
+}
+
+func NewClient(db *sql.DB) *Client {
+	return &Client{db: db}
+}
+
+//IsServerless lazily detects and caches whether the connected endpoint is
+//Redshift Serverless. SYS_SERVERLESS_USAGE only exists on Serverless
+//endpoints, so a query against it is used as the capability probe.
+func (c *Client) IsServerless() bool {
+	c.serverlessOnce.Do(func() {
+		var dummy int
+		err := c.db.QueryRow("SELECT 1 FROM SYS_SERVERLESS_USAGE LIMIT 1").Scan(&dummy)
+		c.isServerless = err == nil || err == sql.ErrNoRows
+	})
+
+	return c.isServerless
+}
+
+func (c *Client) Begin() (*sql.Tx, error) {
+	return c.db.Begin()
+}
+
+func (c *Client) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRow(query, args...)
+}
+
+func (c *Client) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.Exec(query, args...)
+}
@@ -0,0 +1,63 @@
+package redshift
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"strings"
+)
+
+//expandPrivileges turns a privileges TypeSet into a normalised (upper-cased)
+//slice so that comparisons and SQL generation don't have to care about case.
+func expandPrivileges(set *schema.Set) []string {
+	privileges := make([]string, 0, set.Len())
+	for _, v := range set.List() {
+		privileges = append(privileges, strings.ToUpper(v.(string)))
+	}
+	return privileges
+}
+
+//privilegeDiff computes the set difference between the privileges a grant
+//resource already has and the privileges it should have after an update, so
+//that Update only emits the GRANT/REVOKE statements actually needed instead
+//of revoking everything and granting it all back.
+func privilegeDiff(oldPrivileges []string, newPrivileges []string) (toGrant []string, toRevoke []string) {
+	oldSet := make(map[string]bool, len(oldPrivileges))
+	for _, p := range oldPrivileges {
+		oldSet[p] = true
+	}
+	newSet := make(map[string]bool, len(newPrivileges))
+	for _, p := range newPrivileges {
+		newSet[p] = true
+	}
+
+	for p := range newSet {
+		if !oldSet[p] {
+			toGrant = append(toGrant, p)
+		}
+	}
+	for p := range oldSet {
+		if !newSet[p] {
+			toRevoke = append(toRevoke, p)
+		}
+	}
+	return toGrant, toRevoke
+}
+
+//consistentGrantOption collapses the per-privilege WITH GRANT OPTION state
+//read back from the catalog into the single with_grant_option flag this
+//resource exposes. It errors out instead of guessing when privileges
+//disagree, since silently picking one would cause permanent, invisible plan
+//drift whenever a role's grantable privileges don't all match.
+func consistentGrantOption(perPrivilege []bool) (bool, error) {
+	if len(perPrivilege) == 0 {
+		return false, nil
+	}
+
+	first := perPrivilege[0]
+	for _, v := range perPrivilege[1:] {
+		if v != first {
+			return false, fmt.Errorf("privileges have inconsistent WITH GRANT OPTION state; manage them as separate grant resources")
+		}
+	}
+	return first, nil
+}
@@ -6,11 +6,21 @@ package redshift
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/lib/pq"
 	"log"
+	"regexp"
 	"time"
 )
 
+//validateConnectionLimit only accepts the CONNECTION LIMIT syntax Redshift
+//understands (a bare non-negative integer or the UNLIMITED keyword), since
+//the value is concatenated straight into the CREATE/ALTER DATABASE statement
+//rather than passed as a bound parameter.
+var validateConnectionLimit = validation.StringMatch(regexp.MustCompile(`^(UNLIMITED|[0-9]+)$`), "must be \"UNLIMITED\" or a non-negative integer")
+
 func redshiftDatabase() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceRedshiftDatabaseCreate,
@@ -32,9 +42,21 @@ func redshiftDatabase() *schema.Resource {
 				Required: true,
 			},
 			"connection_limit": { //Cluster limit is 500
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  "UNLIMITED",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "UNLIMITED",
+				ValidateFunc: validateConnectionLimit,
+			},
+			"collate": { //CASE_SENSITIVE or CASE_INSENSITIVE, fixed for the life of the database
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"CASE_SENSITIVE", "CASE_INSENSITIVE"}, false),
+			},
+			"isolation_level": { //SERIALIZABLE or SNAPSHOT
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"SERIALIZABLE", "SNAPSHOT"}, false),
 			},
 		},
 	}
@@ -43,97 +65,143 @@ func redshiftDatabase() *schema.Resource {
 func resourceRedshiftDatabaseExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
 	// Exists - This is called to verify a resource still exists. It is called prior to Read,
 	// and lowers the burden of Read to be able to assume the resource exists.
-	client := meta.(*sql.DB)
+	client := meta.(*Client)
 
 	var name string
 
-	err := client.QueryRow("SELECT datname FROM pg_database_info WHERE datid = $1", d.Id()).Scan(&name)
+	var err error
+	if client.IsServerless() {
+		err = client.QueryRow("SELECT database_name FROM SVV_REDSHIFT_DATABASES WHERE database_name = $1", d.Id()).Scan(&name)
+	} else {
+		err = client.QueryRow("SELECT datname FROM pg_database_info WHERE datid = $1", d.Id()).Scan(&name)
+	}
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
 
+//buildCreateDatabaseStatement assembles the `create database` statement,
+//quoting identifiers so names containing uppercase, hyphens, reserved words
+//or quotes round-trip correctly. connectionLimit, collate and isolationLevel
+//are passed through empty when not set.
+func buildCreateDatabaseStatement(name string, ownerName string, connectionLimit string, collate string, isolationLevel string, isServerless bool) string {
+	statement := "create database " + pq.QuoteIdentifier(name)
+
+	if ownerName != "" {
+		statement += " OWNER " + pq.QuoteIdentifier(ownerName)
+	}
+	if connectionLimit != "" && !isServerless {
+		//CONNECTION LIMIT takes an unquoted integer or the UNLIMITED keyword, not a string literal.
+		//Serverless has no notion of a per-database connection limit, so the option is dropped there.
+		statement += " CONNECTION LIMIT " + connectionLimit
+	}
+	if collate != "" {
+		//COLLATE takes the bare keyword CASE_SENSITIVE/CASE_INSENSITIVE, not an identifier or literal
+		statement += " COLLATE " + collate
+	}
+	if isolationLevel != "" {
+		//ISOLATION LEVEL takes the bare keyword SERIALIZABLE/SNAPSHOT
+		statement += " ISOLATION LEVEL " + isolationLevel
+	}
+
+	return statement
+}
+
 func resourceRedshiftDatabaseCreate(d *schema.ResourceData, meta interface{}) error {
 
-	redshiftClient := meta.(*sql.DB)
+	redshiftClient := meta.(*Client)
+	name := d.Get("database_name").(string)
+
 	tx, txErr := redshiftClient.Begin()
 	if txErr != nil {
-		panic(txErr)
+		return fmt.Errorf("creating database %q: %w", name, txErr)
 	}
+	defer tx.Rollback()
 
-	var createStatement string = "create database " + d.Get("database_name").(string)
-
+	var ownerName string
 	if v, ok := d.GetOk("owner"); ok {
-
 		var usernames = GetUsersnamesForUsesysid(tx, []interface{}{v.(int)})
-		createStatement += " OWNER " + usernames[0]
+		ownerName = usernames[0]
 	}
+
+	var connectionLimit string
 	if v, ok := d.GetOk("connection_limit"); ok {
-		createStatement += " CONNECTION LIMIT " + v.(string)
+		connectionLimit = v.(string)
 	}
 
+	createStatement := buildCreateDatabaseStatement(
+		name,
+		ownerName,
+		connectionLimit,
+		d.Get("collate").(string),
+		d.Get("isolation_level").(string),
+		redshiftClient.IsServerless(),
+	)
+
 	log.Print("Create database statement: " + createStatement)
 
 	if _, err := tx.Exec(createStatement); err != nil {
-		log.Fatal(err)
-		return err
+		return fmt.Errorf("creating database %q: %w", name, err)
 	}
 
 	//The changes do not propagate instantly
 	time.Sleep(5 * time.Second)
 
 	var datid string
-	err := tx.QueryRow("SELECT datid FROM pg_database_info WHERE datname = $1", d.Get("database_name").(string)).Scan(&datid)
-
-	if err != nil {
-		log.Fatal(err)
-		return err
+	if redshiftClient.IsServerless() {
+		//Serverless has no pg_database_info/datid; the database name is the only stable handle available
+		datid = name
+	} else {
+		if err := tx.QueryRow("SELECT datid FROM pg_database_info WHERE datname = $1", name).Scan(&datid); err != nil {
+			return fmt.Errorf("creating database %q: %w", name, err)
+		}
 	}
 
 	d.SetId(datid)
 
-	readErr := readRedshiftDatabase(d, tx)
-
-	if readErr == nil {
-		tx.Commit()
-		return nil
-	} else {
-		tx.Rollback()
-		return readErr
+	if err := readRedshiftDatabase(d, tx, redshiftClient.IsServerless()); err != nil {
+		return fmt.Errorf("creating database %q: %w", name, err)
 	}
+
+	return tx.Commit()
 }
 
 func resourceRedshiftDatabaseRead(d *schema.ResourceData, meta interface{}) error {
 
-	redshiftClient := meta.(*sql.DB)
+	redshiftClient := meta.(*Client)
 	tx, txErr := redshiftClient.Begin()
 	if txErr != nil {
-		panic(txErr)
+		return fmt.Errorf("reading database %q: %w", d.Id(), txErr)
 	}
+	defer tx.Rollback()
 
-	err := readRedshiftDatabase(d, tx)
-
-	if err == nil {
-		tx.Commit()
-		return nil
-	} else {
-		tx.Rollback()
-		return err
+	if err := readRedshiftDatabase(d, tx, redshiftClient.IsServerless()); err != nil {
+		return fmt.Errorf("reading database %q: %w", d.Id(), err)
 	}
+
+	return tx.Commit()
 }
 
-func readRedshiftDatabase(d *schema.ResourceData, tx *sql.Tx) error {
+func readRedshiftDatabase(d *schema.ResourceData, tx *sql.Tx, isServerless bool) error {
+	if isServerless {
+		return readRedshiftDatabaseServerless(d, tx)
+	}
+
 	var (
 		databasename string
 		owner        int
 		connlimit    sql.NullString
 	)
 
-	err := tx.QueryRow("select datname, datdba, datconnlimit from pg_database_info where datid = $1", d.Id()).Scan(&databasename, &owner, &connlimit)
+	err := tx.QueryRow("select datname, datdba, datconnlimit from pg_database_info where datid = $1", d.Id()).
+		Scan(&databasename, &owner, &connlimit)
 
+	if err == sql.ErrNoRows {
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		log.Fatal(err)
 		return err
 	}
 
@@ -146,24 +214,68 @@ func readRedshiftDatabase(d *schema.ResourceData, tx *sql.Tx) error {
 		d.Set("connection_limit", nil)
 	}
 
+	var isolationLevel sql.NullString
+	ilErr := tx.QueryRow("select database_isolation_level from SVV_REDSHIFT_DATABASES where database_name = $1", databasename).Scan(&isolationLevel)
+	if ilErr == nil && isolationLevel.Valid {
+		d.Set("isolation_level", isolationLevel.String)
+	}
+
+	return nil
+}
+
+//readRedshiftDatabaseServerless reads back what SVV_REDSHIFT_DATABASES exposes.
+//Serverless has no pg_database_info, so connection_limit and collate are left
+//as whatever is already in state.
+func readRedshiftDatabaseServerless(d *schema.ResourceData, tx *sql.Tx) error {
+	var (
+		databasename   string
+		owner          int
+		isolationLevel sql.NullString
+	)
+
+	err := tx.QueryRow("select database_name, database_owner, database_isolation_level from SVV_REDSHIFT_DATABASES where database_name = $1", d.Id()).
+		Scan(&databasename, &owner, &isolationLevel)
+
+	if err == sql.ErrNoRows {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	d.Set("database_name", databasename)
+	d.Set("owner", owner)
+	if isolationLevel.Valid {
+		d.Set("isolation_level", isolationLevel.String)
+	}
+
 	return nil
 }
 
 func resourceRedshiftDatabaseUpdate(d *schema.ResourceData, meta interface{}) error {
 
-	redshiftClient := meta.(*sql.DB)
+	redshiftClient := meta.(*Client)
+	name := d.Get("database_name").(string)
+
 	tx, txErr := redshiftClient.Begin()
 	if txErr != nil {
-		panic(txErr)
+		return fmt.Errorf("updating database %q: %w", name, txErr)
 	}
+	defer tx.Rollback()
 
 	if d.HasChange("database_name") {
 
 		oldName, newName := d.GetChange("database_name")
-		alterDatabaseNameQuery := "ALTER DATABASE " + oldName.(string) + " rename to " + newName.(string)
+		alterDatabaseNameQuery := "ALTER DATABASE " + pq.QuoteIdentifier(oldName.(string)) + " rename to " + pq.QuoteIdentifier(newName.(string))
 
 		if _, err := tx.Exec(alterDatabaseNameQuery); err != nil {
-			return err
+			return fmt.Errorf("updating database %q: %w", name, err)
+		}
+
+		if redshiftClient.IsServerless() {
+			//Serverless has no stable datid to key off, the database name itself is the ID
+			d.SetId(newName.(string))
 		}
 	}
 
@@ -171,40 +283,48 @@ func resourceRedshiftDatabaseUpdate(d *schema.ResourceData, meta interface{}) er
 
 		var username = GetUsersnamesForUsesysid(tx, []interface{}{d.Get("owner").(int)})
 
-		if _, err := tx.Exec("ALTER DATABASE " + d.Get("database_name").(string) + " OWNER TO " + username[0]); err != nil {
-			return err
+		if _, err := tx.Exec("ALTER DATABASE " + pq.QuoteIdentifier(d.Get("database_name").(string)) + " OWNER TO " + pq.QuoteIdentifier(username[0])); err != nil {
+			return fmt.Errorf("updating database %q: %w", name, err)
 		}
 	}
 
 	//TODO What if value is removed?
-	if d.HasChange("connection_limit") {
-		if _, err := tx.Exec("ALTER DATABASE " + d.Get("database_name").(string) + " CONNECTION LIMIT " + d.Get("connection_limit").(string)); err != nil {
-			return err
+	if d.HasChange("connection_limit") && !redshiftClient.IsServerless() {
+		if _, err := tx.Exec("ALTER DATABASE " + pq.QuoteIdentifier(d.Get("database_name").(string)) + " CONNECTION LIMIT " + d.Get("connection_limit").(string)); err != nil {
+			return fmt.Errorf("updating database %q: %w", name, err)
 		}
 	}
 
-	err := readRedshiftDatabase(d, tx)
+	if d.HasChange("isolation_level") {
+		if _, err := tx.Exec("ALTER DATABASE " + pq.QuoteIdentifier(d.Get("database_name").(string)) + " ISOLATION LEVEL " + d.Get("isolation_level").(string)); err != nil {
+			return fmt.Errorf("updating database %q: %w", name, err)
+		}
+	}
 
-	if err == nil {
-		tx.Commit()
-		return nil
-	} else {
-		tx.Rollback()
-		return err
+	if err := readRedshiftDatabase(d, tx, redshiftClient.IsServerless()); err != nil {
+		return fmt.Errorf("updating database %q: %w", name, err)
 	}
+
+	return tx.Commit()
 }
 
 func resourceRedshiftDatabaseDelete(d *schema.ResourceData, meta interface{}) error {
 
-	client := meta.(*sql.DB)
+	client := meta.(*Client)
+	name := d.Get("database_name").(string)
 
-	_, err := client.Exec("drop database " + d.Get("database_name").(string))
+	_, err := client.Exec("drop database " + pq.QuoteIdentifier(name))
 
 	if err != nil {
-		log.Fatal(err)
-		return err
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "invalid_catalog_name" {
+			//Already gone, e.g. dropped out-of-band - let terraform reconcile instead of erroring
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("deleting database %q: %w", name, err)
 	}
 
+	d.SetId("")
 	return nil
 }
 
@@ -0,0 +1,221 @@
+package redshift
+
+//https://docs.aws.amazon.com/redshift/latest/dg/r_GRANT.html
+//https://docs.aws.amazon.com/redshift/latest/dg/r_REVOKE.html
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/lib/pq"
+	"strings"
+)
+
+//databaseGrantPrivileges are the privileges GRANT/REVOKE accept on a database.
+var databaseGrantPrivileges = []string{"CREATE", "TEMP"}
+
+//validateDatabaseGrantPrivilege restricts each "privileges" set element to a
+//privilege GRANT/REVOKE actually accepts on a database (plus the ALL
+//shorthand), since the values are joined unquoted straight into the
+//GRANT/REVOKE statement rather than passed as bound parameters.
+var validateDatabaseGrantPrivilege = validation.StringInSlice(append([]string{"ALL"}, databaseGrantPrivileges...), true)
+
+func redshiftDatabaseGrant() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRedshiftDatabaseGrantCreate,
+		Read:   resourceRedshiftDatabaseGrantRead,
+		Update: resourceRedshiftDatabaseGrantUpdate,
+		Delete: resourceRedshiftDatabaseGrantDelete,
+		Exists: resourceRedshiftDatabaseGrantExists,
+
+		Schema: map[string]*schema.Schema{
+			"database_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privileges": { //CREATE, TEMP
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString, ValidateFunc: validateDatabaseGrantPrivilege},
+			},
+			"with_grant_option": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceRedshiftDatabaseGrantExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	client := meta.(*Client)
+
+	privileges, _, err := readDatabaseGrant(client, d.Get("database_name").(string), d.Get("role").(string))
+	if err != nil {
+		return false, err
+	}
+	return len(privileges) > 0, nil
+}
+
+func resourceRedshiftDatabaseGrantCreate(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	databaseName := d.Get("database_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("granting privileges on database %q to %q: %w", databaseName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	privileges := expandPrivileges(d.Get("privileges").(*schema.Set))
+
+	if err := grantDatabasePrivileges(tx, databaseName, role, privileges, d.Get("with_grant_option").(bool)); err != nil {
+		return fmt.Errorf("granting privileges on database %q to %q: %w", databaseName, role, err)
+	}
+
+	d.SetId(databaseName + "_" + role)
+
+	return tx.Commit()
+}
+
+func resourceRedshiftDatabaseGrantRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	databaseName := d.Get("database_name").(string)
+	role := d.Get("role").(string)
+
+	privileges, withGrantOption, err := readDatabaseGrant(client, databaseName, role)
+	if err != nil {
+		return fmt.Errorf("reading privileges on database %q for %q: %w", databaseName, role, err)
+	}
+
+	d.Set("privileges", privileges)
+	d.Set("with_grant_option", withGrantOption)
+
+	return nil
+}
+
+func resourceRedshiftDatabaseGrantUpdate(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	databaseName := d.Get("database_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("updating privileges on database %q for %q: %w", databaseName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	if d.HasChange("privileges") {
+		old, new := d.GetChange("privileges")
+		toGrant, toRevoke := privilegeDiff(expandPrivileges(old.(*schema.Set)), expandPrivileges(new.(*schema.Set)))
+
+		if err := revokeDatabasePrivileges(tx, databaseName, role, toRevoke, false); err != nil {
+			return fmt.Errorf("updating privileges on database %q for %q: %w", databaseName, role, err)
+		}
+		if err := grantDatabasePrivileges(tx, databaseName, role, toGrant, d.Get("with_grant_option").(bool)); err != nil {
+			return fmt.Errorf("updating privileges on database %q for %q: %w", databaseName, role, err)
+		}
+	}
+
+	if d.HasChange("with_grant_option") {
+		unchangedPrivileges := expandPrivileges(d.Get("privileges").(*schema.Set))
+
+		if d.Get("with_grant_option").(bool) {
+			if err := grantDatabasePrivileges(tx, databaseName, role, unchangedPrivileges, true); err != nil {
+				return fmt.Errorf("updating privileges on database %q for %q: %w", databaseName, role, err)
+			}
+		} else {
+			if err := revokeDatabasePrivileges(tx, databaseName, role, unchangedPrivileges, true); err != nil {
+				return fmt.Errorf("updating privileges on database %q for %q: %w", databaseName, role, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func resourceRedshiftDatabaseGrantDelete(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	databaseName := d.Get("database_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("revoking privileges on database %q from %q: %w", databaseName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	if err := revokeDatabasePrivileges(tx, databaseName, role, []string{"ALL"}, false); err != nil {
+		return fmt.Errorf("revoking privileges on database %q from %q: %w", databaseName, role, err)
+	}
+
+	return tx.Commit()
+}
+
+func grantDatabasePrivileges(tx *sql.Tx, databaseName string, role string, privileges []string, withGrantOption bool) error {
+	if len(privileges) == 0 {
+		return nil
+	}
+
+	statement := "GRANT " + strings.Join(privileges, ", ") + " ON DATABASE " + pq.QuoteIdentifier(databaseName) + " TO " + pq.QuoteIdentifier(role)
+	if withGrantOption {
+		statement += " WITH GRANT OPTION"
+	}
+
+	_, err := tx.Exec(statement)
+	return err
+}
+
+func revokeDatabasePrivileges(tx *sql.Tx, databaseName string, role string, privileges []string, grantOptionOnly bool) error {
+	if len(privileges) == 0 {
+		return nil
+	}
+
+	statement := "REVOKE "
+	if grantOptionOnly {
+		statement += "GRANT OPTION FOR "
+	}
+	statement += strings.Join(privileges, ", ") + " ON DATABASE " + pq.QuoteIdentifier(databaseName) + " FROM " + pq.QuoteIdentifier(role)
+
+	_, err := tx.Exec(statement)
+	return err
+}
+
+//readDatabaseGrant uses has_database_privilege to read back the privileges a
+//role currently holds on a database, rather than parsing the raw datacl ACL.
+func readDatabaseGrant(client *Client, databaseName string, role string) ([]string, bool, error) {
+	var privileges []string
+	var grantOptions []bool
+
+	for _, privilege := range databaseGrantPrivileges {
+		var granted bool
+		if err := client.QueryRow("SELECT has_database_privilege($1, $2, $3)", role, databaseName, privilege).Scan(&granted); err != nil {
+			return nil, false, err
+		}
+		if !granted {
+			continue
+		}
+		privileges = append(privileges, privilege)
+
+		var withGrantOption bool
+		if err := client.QueryRow("SELECT has_database_privilege($1, $2, $3)", role, databaseName, privilege+" WITH GRANT OPTION").Scan(&withGrantOption); err != nil {
+			return nil, false, err
+		}
+		grantOptions = append(grantOptions, withGrantOption)
+	}
+
+	withGrantOption, err := consistentGrantOption(grantOptions)
+	if err != nil {
+		return nil, false, fmt.Errorf("database %q role %q: %w", databaseName, role, err)
+	}
+
+	return privileges, withGrantOption, nil
+}
@@ -0,0 +1,41 @@
+package redshift
+
+import "testing"
+
+func TestBuildCreateDatabaseStatementQuotesIdentifiers(t *testing.T) {
+	cases := []struct {
+		name     string
+		owner    string
+		expected string
+	}{
+		{name: "MixedCase", owner: "admin", expected: `create database "MixedCase" OWNER "admin"`},
+		{name: "select", owner: "admin", expected: `create database "select" OWNER "admin"`}, //reserved keyword
+		{name: "has-hyphen", owner: "admin", expected: `create database "has-hyphen" OWNER "admin"`},
+		{name: `quo"te`, owner: "admin", expected: `create database "quo""te" OWNER "admin"`},
+	}
+
+	for _, c := range cases {
+		got := buildCreateDatabaseStatement(c.name, c.owner, "", "", "", false)
+		if got != c.expected {
+			t.Errorf("buildCreateDatabaseStatement(%q, %q, ...) = %q, want %q", c.name, c.owner, got, c.expected)
+		}
+	}
+}
+
+func TestBuildCreateDatabaseStatementDropsConnectionLimitOnServerless(t *testing.T) {
+	got := buildCreateDatabaseStatement("db", "", "UNLIMITED", "", "", true)
+	want := `create database "db"`
+
+	if got != want {
+		t.Errorf("buildCreateDatabaseStatement(serverless) = %q, want %q", got, want)
+	}
+}
+
+func TestBuildCreateDatabaseStatementCollateAndIsolationLevel(t *testing.T) {
+	got := buildCreateDatabaseStatement("db", "", "", "CASE_INSENSITIVE", "SNAPSHOT", false)
+	want := `create database "db" COLLATE CASE_INSENSITIVE ISOLATION LEVEL SNAPSHOT`
+
+	if got != want {
+		t.Errorf("buildCreateDatabaseStatement(collate, isolation_level) = %q, want %q", got, want)
+	}
+}
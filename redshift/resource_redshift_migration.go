@@ -0,0 +1,328 @@
+package redshift
+
+//redshiftMigration bootstraps a schema_migrations-style version table in a
+//target database and applies ordered .sql migration files from a directory,
+//mirroring the approach taken by golang-migrate's redshift driver. Files are
+//named "<version>_<description>.up.sql" / "<version>_<description>.down.sql".
+//Redshift has no pg_advisory_lock, so mutual exclusion across concurrent
+//terraform runs is implemented with a dedicated lock table whose single
+//seeded row is claimed by a conditional UPDATE.
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const migrationsTable = "schema_migrations"
+const migrationsLockTable = "schema_migrations_lock"
+
+var migrationFilenamePattern = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+
+type migrationFile struct {
+	version int
+	up      string
+	down    string
+}
+
+func redshiftMigration() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRedshiftMigrationCreate,
+		Read:   resourceRedshiftMigrationRead,
+		Update: resourceRedshiftMigrationUpdate,
+		Delete: resourceRedshiftMigrationDelete,
+
+		Schema: map[string]*schema.Schema{
+			"migrations_directory": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"target_version": { //Applies every discovered migration when unset
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"version": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"dirty": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceRedshiftMigrationCreate(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+
+	if err := ensureMigrationsTables(redshiftClient); err != nil {
+		return fmt.Errorf("bootstrapping %s: %w", migrationsTable, err)
+	}
+
+	d.SetId(d.Get("migrations_directory").(string))
+
+	return applyMigrations(d, redshiftClient)
+}
+
+func resourceRedshiftMigrationRead(d *schema.ResourceData, meta interface{}) error {
+	version, dirty, err := readMigrationState(meta.(*Client))
+	if err != nil {
+		return fmt.Errorf("reading migration state: %w", err)
+	}
+
+	d.Set("version", version)
+	d.Set("dirty", dirty)
+
+	return nil
+}
+
+func resourceRedshiftMigrationUpdate(d *schema.ResourceData, meta interface{}) error {
+	return applyMigrations(d, meta.(*Client))
+}
+
+func resourceRedshiftMigrationDelete(d *schema.ResourceData, meta interface{}) error {
+	//Migrations are not rolled back on resource deletion - removing the
+	//resource just stops terraform from tracking/applying further migrations.
+	d.SetId("")
+	return nil
+}
+
+func ensureMigrationsTables(client *Client) error {
+	if _, err := client.Exec("CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version BIGINT NOT NULL, dirty BOOLEAN NOT NULL)"); err != nil {
+		return err
+	}
+	//Redshift does not enforce PRIMARY KEY/UNIQUE constraints (they're
+	//informational only), so a second concurrent INSERT would silently
+	//succeed rather than fail. The lock row is seeded once here and mutual
+	//exclusion is done later via a conditional UPDATE instead.
+	if _, err := client.Exec("CREATE TABLE IF NOT EXISTS " + migrationsLockTable + " (id INTEGER NOT NULL, locked BOOLEAN NOT NULL)"); err != nil {
+		return err
+	}
+
+	//Seeded with a single INSERT ... SELECT ... WHERE NOT EXISTS rather than a
+	//separate SELECT count(*) followed by an INSERT, since the latter is a
+	//check-then-act race: two concurrent first-time applies could both see
+	//seeded == 0 and both insert an id = 1 row, which would then make
+	//acquireMigrationLock's WHERE id = 1 AND locked = false match two rows
+	//forever. Redshift's default SERIALIZABLE isolation aborts one side of a
+	//genuinely concurrent pair of these statements with a serialization error,
+	//so at most one row ends up seeded.
+	if _, err := client.Exec("INSERT INTO " + migrationsLockTable + " (id, locked) SELECT 1, false WHERE NOT EXISTS (SELECT 1 FROM " + migrationsLockTable + " WHERE id = 1)"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//acquireMigrationLock implements mutual exclusion via a conditional UPDATE
+//rather than an INSERT guarded by a constraint, since Redshift does not
+//enforce PRIMARY KEY/UNIQUE constraints. Only the run that flips locked from
+//false to true gets RowsAffected == 1 and proceeds; a concurrent run sees 0
+//rows affected and backs off instead of silently racing ahead.
+func acquireMigrationLock(client *Client) error {
+	result, err := client.Exec("UPDATE " + migrationsLockTable + " SET locked = true WHERE id = 1 AND locked = false")
+	if err != nil {
+		return fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+	if rows != 1 {
+		return fmt.Errorf("could not acquire migration lock, a migration may already be in progress")
+	}
+
+	return nil
+}
+
+func releaseMigrationLock(client *Client) error {
+	_, err := client.Exec("UPDATE " + migrationsLockTable + " SET locked = false WHERE id = 1")
+	return err
+}
+
+func readMigrationState(client *Client) (int, bool, error) {
+	var version int
+	var dirty bool
+
+	err := client.QueryRow("SELECT version, dirty FROM " + migrationsTable + " ORDER BY version DESC LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+//setMigrationVersion replaces the single row in schema_migrations with the
+//given version/dirty state. The delete and insert are wrapped in one
+//transaction so a crash between them can't leave the table with zero rows,
+//which would otherwise read back as "no migrations applied yet" and cause
+//already-applied migrations to be re-run.
+func setMigrationVersion(client *Client, version int, dirty bool) error {
+	tx, err := client.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM " + migrationsTable); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO "+migrationsTable+" (version, dirty) VALUES ($1, $2)", version, dirty); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//applyMigrations acquires the migration lock, discovers the .sql files on
+//disk and walks the database up or down to target_version (or the latest
+//discovered version when target_version is unset).
+func applyMigrations(d *schema.ResourceData, client *Client) error {
+	if err := acquireMigrationLock(client); err != nil {
+		return err
+	}
+	defer releaseMigrationLock(client)
+
+	migrations, err := discoverMigrations(d.Get("migrations_directory").(string))
+	if err != nil {
+		return fmt.Errorf("discovering migrations: %w", err)
+	}
+
+	currentVersion, dirty, err := readMigrationState(client)
+	if err != nil {
+		return fmt.Errorf("reading migration state: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("%s is marked dirty at version %d, manual intervention required", migrationsTable, currentVersion)
+	}
+
+	targetVersion := currentVersion
+	if v, ok := d.GetOk("target_version"); ok {
+		targetVersion = v.(int)
+	} else if len(migrations) > 0 {
+		targetVersion = migrations[len(migrations)-1].version
+	}
+
+	for _, migration := range migrations {
+		if migration.version <= currentVersion || migration.version > targetVersion {
+			continue
+		}
+		if err := applyMigration(client, migration, true); err != nil {
+			return fmt.Errorf("applying migration %d: %w", migration.version, err)
+		}
+		currentVersion = migration.version
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.version > currentVersion || migration.version <= targetVersion {
+			continue
+		}
+		if err := applyMigration(client, migration, false); err != nil {
+			return fmt.Errorf("reverting migration %d: %w", migration.version, err)
+		}
+		currentVersion = migration.version - 1
+	}
+
+	d.Set("version", currentVersion)
+	d.Set("dirty", false)
+
+	return nil
+}
+
+//applyMigration runs a single up or down migration inside its own
+//transaction, marking schema_migrations dirty for the duration so a crash
+//mid-migration is visible on the next run instead of silently re-applied.
+func applyMigration(client *Client, migration migrationFile, up bool) error {
+	sqlText := migration.up
+	targetVersion := migration.version
+	if !up {
+		sqlText = migration.down
+		targetVersion = migration.version - 1
+	}
+
+	if strings.TrimSpace(sqlText) == "" {
+		return setMigrationVersion(client, targetVersion, false)
+	}
+
+	if err := setMigrationVersion(client, targetVersion, true); err != nil {
+		return err
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(sqlText); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return setMigrationVersion(client, targetVersion, false)
+}
+
+func discoverMigrations(directory string) ([]migrationFile, error) {
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*migrationFile{}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFilenamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing migration version from %q: %w", entry.Name(), err)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migrationFile{version: version}
+			byVersion[version] = m
+		}
+
+		if match[2] == "up" {
+			m.up = string(contents)
+		} else {
+			m.down = string(contents)
+		}
+	}
+
+	migrations := make([]migrationFile, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
@@ -0,0 +1,221 @@
+package redshift
+
+//https://docs.aws.amazon.com/redshift/latest/dg/r_GRANT.html
+//https://docs.aws.amazon.com/redshift/latest/dg/r_REVOKE.html
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/lib/pq"
+	"strings"
+)
+
+//schemaGrantPrivileges are the privileges GRANT/REVOKE accept on a schema.
+var schemaGrantPrivileges = []string{"CREATE", "USAGE"}
+
+//validateSchemaGrantPrivilege restricts each "privileges" set element to a
+//privilege GRANT/REVOKE actually accepts on a schema (plus the ALL
+//shorthand), since the values are joined unquoted straight into the
+//GRANT/REVOKE statement rather than passed as bound parameters.
+var validateSchemaGrantPrivilege = validation.StringInSlice(append([]string{"ALL"}, schemaGrantPrivileges...), true)
+
+func redshiftSchemaGrant() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRedshiftSchemaGrantCreate,
+		Read:   resourceRedshiftSchemaGrantRead,
+		Update: resourceRedshiftSchemaGrantUpdate,
+		Delete: resourceRedshiftSchemaGrantDelete,
+		Exists: resourceRedshiftSchemaGrantExists,
+
+		Schema: map[string]*schema.Schema{
+			"schema_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privileges": { //CREATE, USAGE
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString, ValidateFunc: validateSchemaGrantPrivilege},
+			},
+			"with_grant_option": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceRedshiftSchemaGrantExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	client := meta.(*Client)
+
+	privileges, _, err := readSchemaGrant(client, d.Get("schema_name").(string), d.Get("role").(string))
+	if err != nil {
+		return false, err
+	}
+	return len(privileges) > 0, nil
+}
+
+func resourceRedshiftSchemaGrantCreate(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	schemaName := d.Get("schema_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("granting privileges on schema %q to %q: %w", schemaName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	privileges := expandPrivileges(d.Get("privileges").(*schema.Set))
+
+	if err := grantSchemaPrivileges(tx, schemaName, role, privileges, d.Get("with_grant_option").(bool)); err != nil {
+		return fmt.Errorf("granting privileges on schema %q to %q: %w", schemaName, role, err)
+	}
+
+	d.SetId(schemaName + "_" + role)
+
+	return tx.Commit()
+}
+
+func resourceRedshiftSchemaGrantRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	schemaName := d.Get("schema_name").(string)
+	role := d.Get("role").(string)
+
+	privileges, withGrantOption, err := readSchemaGrant(client, schemaName, role)
+	if err != nil {
+		return fmt.Errorf("reading privileges on schema %q for %q: %w", schemaName, role, err)
+	}
+
+	d.Set("privileges", privileges)
+	d.Set("with_grant_option", withGrantOption)
+
+	return nil
+}
+
+func resourceRedshiftSchemaGrantUpdate(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	schemaName := d.Get("schema_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("updating privileges on schema %q for %q: %w", schemaName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	if d.HasChange("privileges") {
+		old, new := d.GetChange("privileges")
+		toGrant, toRevoke := privilegeDiff(expandPrivileges(old.(*schema.Set)), expandPrivileges(new.(*schema.Set)))
+
+		if err := revokeSchemaPrivileges(tx, schemaName, role, toRevoke, false); err != nil {
+			return fmt.Errorf("updating privileges on schema %q for %q: %w", schemaName, role, err)
+		}
+		if err := grantSchemaPrivileges(tx, schemaName, role, toGrant, d.Get("with_grant_option").(bool)); err != nil {
+			return fmt.Errorf("updating privileges on schema %q for %q: %w", schemaName, role, err)
+		}
+	}
+
+	if d.HasChange("with_grant_option") {
+		unchangedPrivileges := expandPrivileges(d.Get("privileges").(*schema.Set))
+
+		if d.Get("with_grant_option").(bool) {
+			if err := grantSchemaPrivileges(tx, schemaName, role, unchangedPrivileges, true); err != nil {
+				return fmt.Errorf("updating privileges on schema %q for %q: %w", schemaName, role, err)
+			}
+		} else {
+			if err := revokeSchemaPrivileges(tx, schemaName, role, unchangedPrivileges, true); err != nil {
+				return fmt.Errorf("updating privileges on schema %q for %q: %w", schemaName, role, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func resourceRedshiftSchemaGrantDelete(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client)
+	schemaName := d.Get("schema_name").(string)
+	role := d.Get("role").(string)
+
+	tx, txErr := redshiftClient.Begin()
+	if txErr != nil {
+		return fmt.Errorf("revoking privileges on schema %q from %q: %w", schemaName, role, txErr)
+	}
+	defer tx.Rollback()
+
+	if err := revokeSchemaPrivileges(tx, schemaName, role, []string{"ALL"}, false); err != nil {
+		return fmt.Errorf("revoking privileges on schema %q from %q: %w", schemaName, role, err)
+	}
+
+	return tx.Commit()
+}
+
+func grantSchemaPrivileges(tx *sql.Tx, schemaName string, role string, privileges []string, withGrantOption bool) error {
+	if len(privileges) == 0 {
+		return nil
+	}
+
+	statement := "GRANT " + strings.Join(privileges, ", ") + " ON SCHEMA " + pq.QuoteIdentifier(schemaName) + " TO " + pq.QuoteIdentifier(role)
+	if withGrantOption {
+		statement += " WITH GRANT OPTION"
+	}
+
+	_, err := tx.Exec(statement)
+	return err
+}
+
+func revokeSchemaPrivileges(tx *sql.Tx, schemaName string, role string, privileges []string, grantOptionOnly bool) error {
+	if len(privileges) == 0 {
+		return nil
+	}
+
+	statement := "REVOKE "
+	if grantOptionOnly {
+		statement += "GRANT OPTION FOR "
+	}
+	statement += strings.Join(privileges, ", ") + " ON SCHEMA " + pq.QuoteIdentifier(schemaName) + " FROM " + pq.QuoteIdentifier(role)
+
+	_, err := tx.Exec(statement)
+	return err
+}
+
+//readSchemaGrant uses has_schema_privilege to read back the privileges a
+//role currently holds on a schema, rather than parsing the raw nspacl ACL.
+func readSchemaGrant(client *Client, schemaName string, role string) ([]string, bool, error) {
+	var privileges []string
+	var grantOptions []bool
+
+	for _, privilege := range schemaGrantPrivileges {
+		var granted bool
+		if err := client.QueryRow("SELECT has_schema_privilege($1, $2, $3)", role, schemaName, privilege).Scan(&granted); err != nil {
+			return nil, false, err
+		}
+		if !granted {
+			continue
+		}
+		privileges = append(privileges, privilege)
+
+		var withGrantOption bool
+		if err := client.QueryRow("SELECT has_schema_privilege($1, $2, $3)", role, schemaName, privilege+" WITH GRANT OPTION").Scan(&withGrantOption); err != nil {
+			return nil, false, err
+		}
+		grantOptions = append(grantOptions, withGrantOption)
+	}
+
+	withGrantOption, err := consistentGrantOption(grantOptions)
+	if err != nil {
+		return nil, false, fmt.Errorf("schema %q role %q: %w", schemaName, role, err)
+	}
+
+	return privileges, withGrantOption, nil
+}